@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOut  string
+	exportGzip bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export a legends world as a navigable static site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := args[0]
+
+		w, err := model.Parse(file, &model.LoadProgress{})
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", file, err)
+		}
+
+		srv, err := server.NewExportServer(w, static)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("exporting to", exportOut)
+		return srv.Export(exportOut, time.Now(), exportGzip)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportOut, "out", "export", "directory to write the static site to")
+	exportCmd.Flags().BoolVar(&exportGzip, "gzip", false, "also write .html.gz siblings for nginx gzip_static")
+	rootCmd.AddCommand(exportCmd)
+}
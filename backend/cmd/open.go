@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/server"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <file>",
+	Short: "Open a legends export and serve it locally",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := args[0]
+
+		w, err := model.Parse(file, &model.LoadProgress{})
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", file, err)
+		}
+
+		config.ServerMode = false
+		config.LastPath = file
+		if err := config.Save(); err != nil {
+			fmt.Println("failed to save config:", err)
+		}
+
+		server.StartServer(w, static, config)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
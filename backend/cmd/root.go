@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"os"
+
+	"github.com/robertjanetzko/LegendsBrowser2/backend/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	static         embed.FS
+	configPath     string
+	feedMaxEntries int
+	config         *server.Config
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "legendsbrowser",
+	Short: "Browse Dwarf Fortress legends exports",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		path := configPath
+		if path == "" {
+			p, err := server.DefaultConfigPath()
+			if err != nil {
+				return err
+			}
+			path = p
+		}
+
+		cfg, err := server.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		config = cfg
+
+		if cmd.Flags().Changed("feed-max-entries") {
+			config.FeedMaxEntries = feedMaxEntries
+		}
+
+		return nil
+	},
+}
+
+// Execute runs the legendsbrowser command tree, serving static with the
+// embedded frontend assets.
+func Execute(staticFS embed.FS) {
+	static = staticFS
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file (default ~/.legendsbrowser2.yaml)")
+	rootCmd.PersistentFlags().IntVar(&feedMaxEntries, "feed-max-entries", 0, "maximum entries per Atom feed (0 = default)")
+}
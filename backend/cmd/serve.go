@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/robertjanetzko/LegendsBrowser2/backend/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveWorldsDir string
+	serveBind      string
+	serveAddr      string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run as a multi-world server, offering pre-configured worlds from worlds-dir",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config.ServerMode = true
+		if cmd.Flags().Changed("worlds-dir") {
+			config.WorldsDir = serveWorldsDir
+		}
+		if cmd.Flags().Changed("bind") {
+			config.Bind = serveBind
+		}
+		if cmd.Flags().Changed("addr") {
+			config.Addr = serveAddr
+		}
+
+		server.StartServer(nil, static, config)
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveWorldsDir, "worlds-dir", "", "directory of legends exports to serve")
+	serveCmd.Flags().StringVar(&serveBind, "bind", "localhost", "address to bind to")
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "port to listen on, e.g. :8080 (appended to --bind)")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var worldsCmd = &cobra.Command{
+	Use:   "worlds",
+	Short: "Manage worlds available to server mode",
+}
+
+var worldsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the legends exports found in worlds-dir",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := ioutil.ReadDir(config.WorldsDir)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), "-legends.xml") {
+				fmt.Println(e.Name())
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	worldsCmd.AddCommand(worldsListCmd)
+	rootCmd.AddCommand(worldsCmd)
+}
@@ -0,0 +1,213 @@
+// Package export renders a DfServer's registered pages to a static site on
+// disk, so a world can be browsed via file:// or any static host without a
+// running Go server.
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robertjanetzko/LegendsBrowser2/backend/templates"
+)
+
+// Page is a single static (non-resource) route, e.g. "/entities".
+type Page struct {
+	Path     string
+	Template string
+	Accessor func() any
+}
+
+// ResourcePage is a route with one page per id in a world map, e.g.
+// "/entity/{id}".
+type ResourcePage struct {
+	Path     string
+	Template string
+	Ids      func() []int
+	Accessor func(id int) any
+}
+
+// Exporter accumulates the pages a DfServer has registered so they can
+// later be rendered to disk as a static site.
+type Exporter struct {
+	Pages         []Page
+	ResourcePages []ResourcePage
+	LoadTime      time.Time
+}
+
+// New creates an empty Exporter.
+func New(loadTime time.Time) *Exporter {
+	return &Exporter{LoadTime: loadTime}
+}
+
+// RecordPage remembers a RegisterWorldPage call for later export.
+func (e *Exporter) RecordPage(path, tmpl string, accessor func() any) {
+	e.Pages = append(e.Pages, Page{Path: path, Template: tmpl, Accessor: accessor})
+}
+
+// RecordResourcePage remembers a RegisterWorldResourcePage call for later
+// export.
+func (e *Exporter) RecordResourcePage(pathTmpl, tmpl string, ids func() []int, accessor func(id int) any) {
+	e.ResourcePages = append(e.ResourcePages, ResourcePage{Path: pathTmpl, Template: tmpl, Ids: ids, Accessor: accessor})
+}
+
+// sitemapUrl is one <url> entry in sitemap.xml.
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemap struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+var hrefRe = regexp.MustCompile(`(href|src)="(/[^"#?]*)`)
+
+// Export renders every recorded page into outDir/<path>/index.html, copies
+// staticFS verbatim, and writes outDir/sitemap.xml. If gzipSiblings is true, each
+// index.html gets a sibling index.html.gz for nginx gzip_static.
+func Export(e *Exporter, outDir string, t *templates.Template, staticFS fs.FS, gzipSiblings bool) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	var urls []sitemapUrl
+	lastMod := e.LoadTime.Format(time.RFC3339)
+
+	render := func(urlPath, tmpl string, data any) error {
+		var buf bytes.Buffer
+		if err := t.Render(&buf, tmpl, data); err != nil {
+			return fmt.Errorf("rendering %s: %w", urlPath, err)
+		}
+
+		rewritten := rewriteLinks(buf.String(), urlPath)
+
+		dir := filepath.Join(outDir, filepath.FromSlash(urlPath))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		file := filepath.Join(dir, "index.html")
+		if err := os.WriteFile(file, []byte(rewritten), 0644); err != nil {
+			return err
+		}
+		if gzipSiblings {
+			if err := writeGzip(file+".gz", rewritten); err != nil {
+				return err
+			}
+		}
+
+		urls = append(urls, sitemapUrl{Loc: urlPath, LastMod: lastMod})
+		return nil
+	}
+
+	for _, p := range e.Pages {
+		if err := render(p.Path, p.Template, p.Accessor()); err != nil {
+			return err
+		}
+	}
+
+	for _, rp := range e.ResourcePages {
+		for _, id := range rp.Ids() {
+			urlPath := strings.Replace(rp.Path, "{id}", fmt.Sprint(id), 1)
+			if err := render(urlPath, rp.Template, rp.Accessor(id)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := copyStatic(staticFS, outDir); err != nil {
+		return err
+	}
+
+	return writeSitemap(outDir, urls)
+}
+
+// rewriteLinks turns absolute hrefs ("/hf/5") into paths relative to
+// urlPath, so the exported tree works from file:// and from any static
+// host's subdirectory.
+func rewriteLinks(html, urlPath string) string {
+	trimmed := strings.Trim(urlPath, "/")
+	depth := 0
+	if trimmed != "" {
+		depth = strings.Count(trimmed, "/") + 1
+	}
+	prefix := strings.Repeat("../", depth)
+
+	return hrefRe.ReplaceAllStringFunc(html, func(m string) string {
+		parts := hrefRe.FindStringSubmatch(m)
+		target := strings.TrimPrefix(parts[2], "/")
+		// Pages are written as <path>/index.html; file:// does not resolve a
+		// directory to its index.html, so link to the file itself.
+		if path.Ext(target) == "" {
+			target = strings.TrimSuffix(target, "/")
+			if target != "" {
+				target += "/"
+			}
+			target += "index.html"
+		}
+		return fmt.Sprintf(`%s="%s%s`, parts[1], prefix, target)
+	})
+}
+
+func copyStatic(staticFS fs.FS, outDir string) error {
+	return fs.WalkDir(staticFS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(staticFS, p)
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(outDir, "static", filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dest, data, 0644)
+	})
+}
+
+func writeGzip(file, content string) error {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte(content)); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
+func writeSitemap(outDir string, urls []sitemapUrl) error {
+	sm := sitemap{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9", Urls: urls}
+
+	f, err := os.Create(filepath.Join(outDir, "sitemap.xml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(sm)
+}
@@ -0,0 +1,50 @@
+// Package feed renders Atom 1.0 feeds for the historical event streams
+// exposed by the DF server, so readers can subscribe instead of polling
+// /events/{type}.
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed is a minimal Atom 1.0 <feed> document, covering only the elements
+// this package emits.
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Entries []Entry  `xml:"entry"`
+}
+
+type Link struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type Entry struct {
+	Id      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Author  *Person  `xml:"author,omitempty"`
+	Content Content  `xml:"content"`
+}
+
+type Person struct {
+	Name string `xml:"name"`
+}
+
+type Content struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",cdata"`
+}
+
+// AtomTime formats t per RFC 3339, the timestamp format Atom requires for
+// <updated>.
+func AtomTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
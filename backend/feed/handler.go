@@ -0,0 +1,232 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+)
+
+// defaultMaxEntries is the cap on entries per feed used when
+// RegisterRoutes/NewBuilder aren't given a positive override.
+const defaultMaxEntries = 200
+
+// Builder renders *model.HistoricalEvent slices as Atom feeds. World is
+// called at request time rather than captured once, so a feed always
+// reflects whichever world is currently loaded, even after a server-mode
+// world switch.
+type Builder struct {
+	Host       string
+	Addr       string
+	World      func() *model.DfWorld
+	MaxEntries int
+	content    *template.Template
+}
+
+// entryTemplate renders an entry's <content>, linking back to the feed's
+// subject hf/entity/site (whichever is set) with the same functions the
+// page templates use before the event's own description.
+const entryTemplate = `` +
+	`{{if .HfId}}{{hf .HfId}} {{end}}` +
+	`{{if .EntityId}}{{entity .EntityId}} {{end}}` +
+	`{{if .SiteId}}{{site .SiteId}} {{end}}` +
+	`<p>{{html .Details}}</p>`
+
+// entryData adapts a HistoricalEvent and its feed's subject (if any) for
+// entryTemplate. Exactly one of HfId/EntityId/SiteId is set, matching
+// whichever /feed/{hf,entity,site}/{id}.atom route the entry came from; all
+// three are zero for the generic /feed/events/{type}.atom feed.
+type entryData struct {
+	*model.HistoricalEvent
+	HfId     int
+	EntityId int
+	SiteId   int
+}
+
+// NewBuilder creates a Builder that links back to host (the tag-URI
+// authority) and host+addr (the plain-http <link> hrefs feed readers
+// actually dereference), and renders entry content with the same
+// link-building functions the page templates use. world is called at
+// request time so the feed always describes the currently loaded world.
+// maxEntries caps entries per feed; 0 uses the package default.
+func NewBuilder(host, addr string, world func() *model.DfWorld, funcs template.FuncMap, maxEntries int) (*Builder, error) {
+	tpl, err := template.New("entry").Funcs(funcs).Parse(entryTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	return &Builder{
+		Host:       host,
+		Addr:       addr,
+		World:      world,
+		MaxEntries: maxEntries,
+		content:    tpl,
+	}, nil
+}
+
+func (b *Builder) tagId(e *model.HistoricalEvent) string {
+	return fmt.Sprintf("tag:%s,%d:event/%d", b.Host, tagYear(b.World()), e.Id_)
+}
+
+// selfHref builds the plain-http URL a feed's own rel="self" link should
+// advertise, combining the bind host and listen address the same way
+// StartServer does for the listener itself.
+func (b *Builder) selfHref(id string) string {
+	return fmt.Sprintf("http://%s%s/feed/%s.atom", b.Host, b.Addr, id)
+}
+
+func (b *Builder) entry(title string, e *model.HistoricalEvent, subjectKind string, subjectId int) (Entry, error) {
+	data := entryData{HistoricalEvent: e}
+	switch subjectKind {
+	case "hf":
+		data.HfId = subjectId
+	case "entity":
+		data.EntityId = subjectId
+	case "site":
+		data.SiteId = subjectId
+	}
+
+	var body strings.Builder
+	if err := b.content.Execute(&body, data); err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Id:      b.tagId(e),
+		Title:   fmt.Sprintf("%s: %s", e.Details.Type(), title),
+		Updated: AtomTime(model.Time(e)),
+		Content: Content{Type: "html", Body: body.String()},
+	}
+
+	if hf := e.PrimaryHf(); hf != nil {
+		entry.Author = &Person{Name: hf.Name()}
+	}
+
+	return entry, nil
+}
+
+// Feed builds an Atom feed titled title from events, sorted by Id_
+// descending and capped at b.MaxEntries. subjectKind/subjectId identify the
+// feed's subject ("hf", "entity" or "site", with its id) so entries can
+// link back to it; pass "", 0 for the generic events-by-type feed.
+func (b *Builder) Feed(id, title string, events []*model.HistoricalEvent, subjectKind string, subjectId int) (*Feed, error) {
+	sort.Slice(events, func(i, j int) bool { return events[i].Id_ > events[j].Id_ })
+	if len(events) > b.MaxEntries {
+		events = events[:b.MaxEntries]
+	}
+
+	feed := &Feed{
+		Id:    fmt.Sprintf("tag:%s,%d:%s", b.Host, tagYear(b.World()), id),
+		Title: title,
+		Links: []Link{
+			{Rel: "self", Type: "application/atom+xml", Href: b.selfHref(id)},
+		},
+	}
+
+	for _, e := range events {
+		entry, err := b.entry(title, e, subjectKind, subjectId)
+		if err != nil {
+			return nil, err
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	}
+
+	return feed, nil
+}
+
+// RegisterRoutes wires the /feed/... endpoints into router. world is called
+// on every request rather than once at registration, so the feeds keep
+// serving the currently loaded world across server-mode world switches.
+// host+addr build the self-link URL readers dereference; maxEntries caps
+// entries per feed, 0 uses the package default.
+func RegisterRoutes(router *mux.Router, world func() *model.DfWorld, host, addr string, funcs template.FuncMap, maxEntries int) error {
+	b, err := NewBuilder(host, addr, world, funcs, maxEntries)
+	if err != nil {
+		return err
+	}
+
+	serve := func(w http.ResponseWriter, r *http.Request, id, title string, events []*model.HistoricalEvent, subjectKind string, subjectId int) {
+		feed, err := b.Feed(id, title, events, subjectKind, subjectId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		fmt.Fprint(w, xmlHeader)
+		if err := xmlEncode(w, feed); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	router.HandleFunc("/feed/events/{type}.atom", func(w http.ResponseWriter, r *http.Request) {
+		t := mux.Vars(r)["type"]
+		serve(w, r, "events/"+t, t, world().EventsOfType(t).Events, "", 0)
+	}).Methods("GET")
+
+	router.HandleFunc("/feed/hf/{id}.atom", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		hf := world().HistoricalFigures[id]
+		if hf == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, r, fmt.Sprintf("hf/%d", id), hf.Name(), world().EventsForHf(id), "hf", id)
+	}).Methods("GET")
+
+	router.HandleFunc("/feed/entity/{id}.atom", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		entity := world().Entities[id]
+		if entity == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, r, fmt.Sprintf("entity/%d", id), entity.Name(), world().EventsForEntity(id), "entity", id)
+	}).Methods("GET")
+
+	router.HandleFunc("/feed/site/{id}.atom", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(mux.Vars(r)["id"])
+		site := world().Sites[id]
+		if site == nil {
+			http.NotFound(w, r)
+			return
+		}
+		serve(w, r, fmt.Sprintf("site/%d", id), site.Name(), world().EventsForSite(id), "site", id)
+	}).Methods("GET")
+
+	return nil
+}
+
+// tagYear returns the in-game year of the world's most recent event, used
+// as the date component of every feed's tag-URI authority.
+func tagYear(world *model.DfWorld) int {
+	year := 0
+	for _, e := range world.HistoricalEvents {
+		if y := model.Time(e).Year(); y > year {
+			year = y
+		}
+	}
+	return year
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func xmlEncode(w io.Writer, feed *Feed) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
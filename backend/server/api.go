@@ -0,0 +1,347 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// resourceList is the envelope every /api/<resource> list endpoint returns.
+type resourceList struct {
+	Total int   `json:"total"`
+	Items []any `json:"items"`
+}
+
+// apiResource records a RegisterResource call so /api/schema can describe it.
+type apiResource struct {
+	Name string
+	Type reflect.Type
+}
+
+var apiResources []apiResource
+
+// RegisterResource exposes resources as a filterable, paginated JSON API at
+// /api/<resourceName> (list) and /api/<resourceName>/{id} (single item).
+// resources is called on every request rather than once at registration, so
+// the API keeps serving the currently loaded world across server-mode world
+// switches.
+//
+// The list endpoint accepts:
+//   - q: case-insensitive substring match on Name()
+//   - type: exact match on Type(), for resources implementing model.Typed
+//   - offset, limit: pagination (limit defaults to 100, capped at 1000)
+//   - sort: "name" or "id", order: "asc" or "desc"
+func RegisterResource[T model.Named](router *mux.Router, resourceName string, resources func() map[int]T) {
+	apiResources = append(apiResources, apiResource{Name: resourceName, Type: reflect.TypeOf((*T)(nil)).Elem()})
+
+	list := func(w http.ResponseWriter, r *http.Request) {
+		values := filterAndSort(resources(), r.URL.Query())
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(values)
+	}
+
+	get := func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resources()[id])
+	}
+
+	router.HandleFunc(fmt.Sprintf("/api/%s", resourceName), list).Methods("GET")
+	router.HandleFunc(fmt.Sprintf("/api/%s/{id}", resourceName), get).Methods("GET")
+}
+
+func filterAndSort[T model.Named](resources map[int]T, q url.Values) resourceList {
+	search := strings.ToLower(q.Get("q"))
+	typeFilter := q.Get("type")
+
+	var items []T
+	for _, v := range resources {
+		if search != "" && !strings.Contains(strings.ToLower(v.Name()), search) {
+			continue
+		}
+		if typeFilter != "" {
+			typed, ok := any(v).(model.Typed)
+			if !ok || typed.Type() != typeFilter {
+				continue
+			}
+		}
+		items = append(items, v)
+	}
+
+	desc := q.Get("order") == "desc"
+	switch q.Get("sort") {
+	case "id":
+		sort.Slice(items, func(i, j int) bool {
+			if desc {
+				return items[i].Id() > items[j].Id()
+			}
+			return items[i].Id() < items[j].Id()
+		})
+	default:
+		sort.Slice(items, func(i, j int) bool {
+			if desc {
+				return items[i].Name() > items[j].Name()
+			}
+			return items[i].Name() < items[j].Name()
+		})
+	}
+
+	total := len(items)
+
+	offset := parseIntDefault(q.Get("offset"), 0)
+	limit := parseIntDefault(q.Get("limit"), defaultLimit)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	page := make([]any, 0, end-offset)
+	for _, v := range items[offset:end] {
+		page = append(page, v)
+	}
+
+	return resourceList{Total: total, Items: page}
+}
+
+func parseIntDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// RegisterEventsAPI exposes /api/events, filtered by subject_hf,
+// subject_entity, type, year_min and year_max, streaming matches as
+// newline-delimited JSON so large worlds don't have to be buffered. world is
+// called on every request rather than once at registration, so /api/events
+// keeps serving the currently loaded world across server-mode world
+// switches.
+func RegisterEventsAPI(router *mux.Router, world func() *model.DfWorld) {
+	router.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		subjectHf := parseIntDefault(q.Get("subject_hf"), 0)
+		subjectEntity := parseIntDefault(q.Get("subject_entity"), 0)
+		eventType := q.Get("type")
+		yearMin := parseIntDefault(q.Get("year_min"), 0)
+		yearMax := parseIntDefault(q.Get("year_max"), 0)
+
+		world := world()
+		events := world.HistoricalEvents
+		if eventType != "" {
+			events = world.EventsOfType(eventType).Events
+		}
+		if subjectHf != 0 {
+			events = intersectEvents(events, world.EventsForHf(subjectHf))
+		}
+		if subjectEntity != 0 {
+			events = intersectEvents(events, world.EventsForEntity(subjectEntity))
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		bw := bufio.NewWriter(w)
+		defer bw.Flush()
+
+		enc := json.NewEncoder(bw)
+		for _, e := range events {
+			year := model.Time(e).Year()
+			if yearMin != 0 && year < yearMin {
+				continue
+			}
+			if yearMax != 0 && year > yearMax {
+				continue
+			}
+
+			if err := enc.Encode(e); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+	}).Methods("GET")
+}
+
+// intersectEvents returns the events in a that also appear in b, comparing
+// by Id_. Used to AND together the independent subject/type filters on
+// /api/events, each of which is backed by its own DfWorld lookup.
+func intersectEvents(a, b []*model.HistoricalEvent) []*model.HistoricalEvent {
+	ids := make(map[int]bool, len(b))
+	for _, e := range b {
+		ids[e.Id_] = true
+	}
+
+	result := make([]*model.HistoricalEvent, 0, len(a))
+	for _, e := range a {
+		if ids[e.Id_] {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// RegisterSchemaAPI exposes /api/schema: an OpenAPI 3.0 document describing
+// every resource registered via RegisterResource, generated by reflecting
+// over each resource's struct fields.
+func RegisterSchemaAPI(router *mux.Router) {
+	router.HandleFunc("/api/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openAPIDocument())
+	}).Methods("GET")
+}
+
+func openAPIDocument() map[string]any {
+	paths := map[string]any{}
+	schemas := map[string]any{}
+
+	for _, res := range apiResources {
+		elemType := res.Type
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		schemaName := elemType.Name()
+		schemas[schemaName] = schemaOf(res.Type)
+
+		ref := map[string]any{"$ref": "#/components/schemas/" + schemaName}
+		paths[fmt.Sprintf("/api/%s", res.Name)] = map[string]any{
+			"get": map[string]any{
+				"summary": fmt.Sprintf("List %s", res.Name),
+				"parameters": []map[string]any{
+					{"name": "q", "in": "query", "schema": map[string]any{"type": "string"}},
+					{"name": "type", "in": "query", "schema": map[string]any{"type": "string"}},
+					{"name": "offset", "in": "query", "schema": map[string]any{"type": "integer"}},
+					{"name": "limit", "in": "query", "schema": map[string]any{"type": "integer"}},
+					{"name": "sort", "in": "query", "schema": map[string]any{"type": "string", "enum": []string{"name", "id"}}},
+					{"name": "order", "in": "query", "schema": map[string]any{"type": "string", "enum": []string{"asc", "desc"}}},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "OK",
+						"content": map[string]any{
+							"application/json": map[string]any{
+								"schema": map[string]any{
+									"type": "object",
+									"properties": map[string]any{
+										"total": map[string]any{"type": "integer"},
+										"items": map[string]any{"type": "array", "items": ref},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		paths[fmt.Sprintf("/api/%s/{id}", res.Name)] = map[string]any{
+			"get": map[string]any{
+				"summary": fmt.Sprintf("Get a single %s", res.Name),
+				"parameters": []map[string]any{
+					{"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "integer"}},
+				},
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "OK",
+						"content": map[string]any{
+							"application/json": map[string]any{"schema": ref},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.0",
+		"info": map[string]any{
+			"title":   "LegendsBrowser2 API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+}
+
+// schemaOf builds a shallow OpenAPI schema object from t's exported fields.
+func schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return map[string]any{"type": jsonType(t)}
+	}
+
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		properties[name] = map[string]any{"type": jsonType(f.Type)}
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the persisted settings for a DfServer instance, loaded from
+// and saved back to ~/.legendsbrowser2.yaml.
+type Config struct {
+	Bind       string `yaml:"bind"`
+	Addr       string `yaml:"addr"`
+	ServerMode bool   `yaml:"serverMode"`
+	LastPath   string `yaml:"lastPath"`
+	WorldsDir  string `yaml:"worldsDir"`
+
+	// FeedMaxEntries caps entries per Atom feed. 0 uses feed.defaultMaxEntries.
+	FeedMaxEntries int `yaml:"feedMaxEntries"`
+
+	path string
+}
+
+// DefaultConfigPath returns the path to the user's config file,
+// ~/.legendsbrowser2.yaml.
+func DefaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".legendsbrowser2.yaml"), nil
+}
+
+// LoadConfig reads the config at path, returning a zero-value Config with
+// sane defaults if the file does not exist yet.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{
+		Bind: "localhost",
+		Addr: ":8080",
+		path: path,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	cfg.path = path
+
+	return cfg, nil
+}
+
+// Save writes the config back to the path it was loaded from.
+func (c *Config) Save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
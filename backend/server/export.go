@@ -0,0 +1,41 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/export"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+)
+
+// NewExportServer builds a DfServer wired up with an Exporter instead of a
+// live router, for `legendsbrowser export`.
+func NewExportServer(world *model.DfWorld, static embed.FS) (*DfServer, error) {
+	srv := &DfServer{
+		router: mux.NewRouter().StrictSlash(true),
+		context: &DfServerContext{
+			world: world,
+		},
+		Exporter: export.New(time.Now()),
+		static:   static,
+	}
+	srv.loader = &loadHandler{server: srv}
+	srv.LoadTemplates()
+	srv.registerPages()
+
+	return srv, nil
+}
+
+// Export renders every registered page to outDir as a static site.
+func (srv *DfServer) Export(outDir string, loadTime time.Time, gzipSiblings bool) error {
+	srv.Exporter.LoadTime = loadTime
+
+	staticFS, err := fs.Sub(srv.static, "static")
+	if err != nil {
+		return err
+	}
+
+	return export.Export(srv.Exporter, outDir, srv.templates, staticFS, gzipSiblings)
+}
@@ -0,0 +1,357 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/util"
+	"github.com/shirou/gopsutil/disk"
+)
+
+type loadHandler struct {
+	server *DfServer
+}
+
+type loadProgress struct {
+	Msg      string  `json:"msg"`
+	Progress float64 `json:"progress"`
+	Done     bool    `json:"done"`
+}
+
+func (h loadHandler) Progress() *loadProgress {
+	percent := 0.0
+	p := h.server.context.progress
+	if p.ProgressBar != nil {
+		percent = float64(p.ProgressBar.Current()*100) / float64(p.ProgressBar.Total())
+	}
+
+	return &loadProgress{
+		Msg:      h.server.context.progress.Message,
+		Progress: percent,
+		Done:     h.server.context.world != nil,
+	}
+}
+
+func (h loadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/load/progress" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+
+		json.NewEncoder(w).Encode(h.Progress())
+		return
+	}
+
+	config := h.server.context.config
+
+	if config != nil && config.ServerMode {
+		worlds, err := worldsInDir(config.WorldsDir)
+		if err != nil {
+			httpError(w, err)
+			return
+		}
+
+		if selected := r.URL.Query().Get("world"); selected != "" {
+			for _, name := range worlds {
+				if name == selected {
+					h.server.context.isLoading = true
+					h.server.context.world = nil
+					go loadWorld(h.server, filepath.Join(config.WorldsDir, selected))
+					http.Redirect(w, r, "/", http.StatusSeeOther)
+					return
+				}
+			}
+		}
+
+		err = h.server.templates.Render(w, "serverMode.gohtml", struct {
+			WorldsDir string
+			Worlds    []string
+		}{
+			WorldsDir: config.WorldsDir,
+			Worlds:    worlds,
+		})
+		if err != nil {
+			httpError(w, err)
+		}
+		return
+	}
+
+	var partitions []string
+	if runtime.GOOS == "windows" {
+		ps, _ := disk.Partitions(false)
+		partitions = util.Map(ps, func(p disk.PartitionStat) string { return p.Mountpoint + `\` })
+	} else {
+		partitions = append(partitions, "/")
+	}
+
+	path := r.URL.Query().Get("p")
+	if path == "" && config != nil {
+		path = config.LastPath
+	}
+
+	current := path
+	if current == "" {
+		current = "."
+	}
+	current, err := filepath.Abs(current)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	f, err := os.Stat(current)
+	if err != nil {
+		http.Redirect(w, r, "/load", http.StatusSeeOther)
+		return
+	}
+
+	if !f.IsDir() {
+		h.server.context.isLoading = true
+		h.server.context.world = nil
+		// LastPath is persisted by loadWorld once parsing actually
+		// succeeds, not here, so a bad path doesn't get saved as the
+		// default for next time.
+		go loadWorld(h.server, current)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	entries, err := ioutil.ReadDir(current)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	p := newPaths(partitions, current, entries, r.URL.Query())
+
+	if r.Header.Get("X-Requested-With") == "fetch" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+		return
+	}
+
+	if err := h.server.templates.Render(w, "load.gohtml", p); err != nil {
+		httpError(w, err)
+	}
+}
+
+// worldsInDir lists the *-legends.xml world files available under dir for
+// the server-mode world chooser.
+func worldsInDir(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var worlds []string
+	for _, e := range entries {
+		if !e.IsDir() && isLegendsXml(e) {
+			worlds = append(worlds, e.Name())
+		}
+	}
+	sort.Strings(worlds)
+
+	return worlds, nil
+}
+
+func isLegendsXml(f fs.FileInfo) bool {
+	return strings.HasSuffix(f.Name(), "-legends.xml")
+}
+
+func loadWorld(server *DfServer, file string) {
+	runtime.GC()
+	wrld, _ := model.Parse(file, server.context.progress)
+	server.context.world = wrld
+	server.context.isLoading = false
+	if wrld != nil {
+		server.registerWorldRoutes(server.context.config)
+
+		if config := server.context.config; config != nil {
+			config.LastPath = file
+			if err := config.Save(); err != nil {
+				fmt.Println("failed to save config:", err)
+			}
+		}
+	}
+}
+
+// legendsBundleSuffixes are the sibling files DF exports alongside
+// *-legends.xml that should be parsed together as one world.
+var legendsBundleSuffixes = []string{"-legends_plus.xml", "-world_history.txt", "-world_sites_and_pops.txt"}
+
+// breadcrumb is one clickable segment of paths.Current.
+type breadcrumb struct {
+	Name string
+	Path string
+}
+
+// entry describes a single file or directory in a Caddy-style browse
+// listing, with the extra metadata the load page needs to spot and group
+// Legends exports.
+type entry struct {
+	Name         string
+	IsDir        bool
+	Size         int64
+	SizeHuman    string
+	ModTime      time.Time
+	IsLegendsXml bool
+	Bundle       []string
+}
+
+// paths is the load.gohtml view model: the current directory's contents,
+// browse state (sort/order/limit), and enough breadcrumb/partition data to
+// navigate without a full page reload.
+type paths struct {
+	Current     string
+	Breadcrumbs []breadcrumb
+	Partitions  []string
+	List        []entry
+	Sort        string
+	Order       string
+	Limit       int
+	NumDirs     int
+	NumFiles    int
+	CanGoUp     bool
+}
+
+// newPaths builds the load page's view model from a directory listing,
+// applying the sort/order/limit query parameters, flagging every
+// *-legends.xml file and listing whichever of its parse-together siblings
+// are present.
+func newPaths(partitions []string, current string, rawEntries []fs.FileInfo, q url.Values) *paths {
+	byName := make(map[string]bool, len(rawEntries))
+	for _, f := range rawEntries {
+		byName[f.Name()] = true
+	}
+
+	entries := make([]entry, 0, len(rawEntries))
+	numDirs, numFiles := 0, 0
+	for _, f := range rawEntries {
+		if f.IsDir() {
+			numDirs++
+		} else {
+			numFiles++
+		}
+
+		e := entry{
+			Name:      f.Name(),
+			IsDir:     f.IsDir(),
+			Size:      f.Size(),
+			SizeHuman: humanSize(f.Size()),
+			ModTime:   f.ModTime(),
+		}
+
+		if !f.IsDir() && isLegendsXml(f) {
+			e.IsLegendsXml = true
+			base := strings.TrimSuffix(f.Name(), "-legends.xml")
+			for _, suffix := range legendsBundleSuffixes {
+				if sibling := base + suffix; byName[sibling] {
+					e.Bundle = append(e.Bundle, sibling)
+				}
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	sortField := q.Get("sort")
+	order := q.Get("order")
+	sortEntries(entries, sortField, order)
+
+	limit := parseIntDefault(q.Get("limit"), 0)
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+
+	parent := filepath.Dir(current)
+
+	return &paths{
+		Current:     current,
+		Breadcrumbs: breadcrumbs(current),
+		Partitions:  partitions,
+		List:        entries,
+		Sort:        sortField,
+		Order:       order,
+		Limit:       limit,
+		NumDirs:     numDirs,
+		NumFiles:    numFiles,
+		CanGoUp:     parent != current,
+	}
+}
+
+// sortEntries sorts entries in place by field ("name", "size" or "time",
+// defaulting to "name"), directories first, honoring order ("asc" or
+// "desc", defaulting to "asc").
+func sortEntries(entries []entry, field, order string) {
+	desc := order == "desc"
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if desc {
+			a, b = b, a
+		}
+
+		switch field {
+		case "size":
+			return a.Size < b.Size
+		case "time":
+			return a.ModTime.Before(b.ModTime)
+		default:
+			return a.Name < b.Name
+		}
+	})
+}
+
+// breadcrumbs splits an absolute path into clickable segments, each
+// carrying the full path up to and including that segment.
+func breadcrumbs(current string) []breadcrumb {
+	segments := strings.Split(filepath.ToSlash(current), "/")
+
+	var crumbs []breadcrumb
+	built := ""
+	for _, seg := range segments {
+		if seg == "" {
+			built = "/"
+			continue
+		}
+		if built == "" || built == "/" {
+			built += seg
+		} else {
+			built += "/" + seg
+		}
+		crumbs = append(crumbs, breadcrumb{Name: seg, Path: built})
+	}
+
+	return crumbs
+}
+
+// humanSize formats n bytes as a short human-readable string (1023B, 4.0K,
+// 2.3M, ...), matching the precision typical of `ls -lh`/Caddy's browse
+// middleware.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -4,63 +4,189 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"io/fs"
-	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
-	"runtime"
 	"sort"
-	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/export"
+	"github.com/robertjanetzko/LegendsBrowser2/backend/feed"
 	"github.com/robertjanetzko/LegendsBrowser2/backend/model"
 	"github.com/robertjanetzko/LegendsBrowser2/backend/templates"
 	"github.com/robertjanetzko/LegendsBrowser2/backend/util"
-	"github.com/shirou/gopsutil/disk"
 )
 
 type DfServerContext struct {
 	world     *model.DfWorld
 	isLoading bool
 	progress  *model.LoadProgress
+	config    *Config
 }
 
 type DfServer struct {
-	router    *mux.Router
-	loader    *loadHandler
-	templates *templates.Template
-	context   *DfServerContext
+	router                *mux.Router
+	routerMu              sync.RWMutex
+	loader                *loadHandler
+	templates             *templates.Template
+	context               *DfServerContext
+	Exporter              *export.Exporter
+	static                embed.FS
+	worldRoutesRegistered bool
 }
 
-func StartServer(world *model.DfWorld, static embed.FS) {
+// ServeHTTP delegates to srv.router under routerMu's read lock, so route
+// matching never runs concurrently with registerWorldRoutes mutating the
+// router from the loadWorld goroutine.
+func (srv *DfServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.routerMu.RLock()
+	defer srv.routerMu.RUnlock()
+	srv.router.ServeHTTP(w, r)
+}
+
+// registerWorldRoutes wires up the feed and JSON-API endpoints that need a
+// loaded world. In CLI mode the world is already loaded at startup; in
+// server mode it only becomes available once a user picks a world from
+// serverMode.gohtml/load.gohtml and loadWorld finishes, so this is also
+// called from there, racing against http.ListenAndServe's dispatch loop --
+// routerMu's write lock keeps that safe. It only ever runs once per
+// process: every handler below reads srv.context.world at request time, so
+// a later world switch is picked up without re-registering anything.
+func (srv *DfServer) registerWorldRoutes(config *Config) {
+	srv.routerMu.Lock()
+	defer srv.routerMu.Unlock()
+
+	if srv.worldRoutesRegistered {
+		return
+	}
+	srv.worldRoutesRegistered = true
+
+	currentWorld := func() *model.DfWorld { return srv.context.world }
+
+	host := ""
+	if config != nil {
+		host = config.Bind
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	addr := ""
+	if config != nil {
+		addr = config.Addr
+	}
+	if addr == "" {
+		addr = ":8080"
+	}
+	maxEntries := 0
+	if config != nil {
+		maxEntries = config.FeedMaxEntries
+	}
+	if err := feed.RegisterRoutes(srv.router, currentWorld, host, addr, feedFunctions(), maxEntries); err != nil {
+		fmt.Println("failed to register feeds:", err)
+	}
+
+	RegisterResource(srv.router, "entities", func() map[int]*model.Entity { return srv.context.world.Entities })
+	RegisterResource(srv.router, "hfs", func() map[int]*model.HistoricalFigure { return srv.context.world.HistoricalFigures })
+	RegisterResource(srv.router, "regions", func() map[int]*model.Region { return srv.context.world.Regions })
+	RegisterResource(srv.router, "sites", func() map[int]*model.Site { return srv.context.world.Sites })
+	RegisterResource(srv.router, "artifacts", func() map[int]*model.Artifact { return srv.context.world.Artifacts })
+	RegisterResource(srv.router, "writtencontents", func() map[int]*model.WrittenContent { return srv.context.world.WrittenContents })
+	RegisterResource(srv.router, "worldconstructions", func() map[int]*model.WorldConstruction { return srv.context.world.WorldConstructions })
+	RegisterResource(srv.router, "danceforms", func() map[int]*model.DanceForm { return srv.context.world.DanceForms })
+	RegisterResource(srv.router, "musicalforms", func() map[int]*model.MusicalForm { return srv.context.world.MusicalForms })
+	RegisterResource(srv.router, "poeticforms", func() map[int]*model.PoeticForm { return srv.context.world.PoeticForms })
+
+	RegisterEventsAPI(srv.router, currentWorld)
+	RegisterSchemaAPI(srv.router)
+}
+
+// registerWorldPage forwards to RegisterWorldPage and, when srv.Exporter is
+// set (i.e. we're building for `legendsbrowser export`), also records the
+// route so it can be rendered to disk later.
+func (srv *DfServer) registerWorldPage(path, tmpl string, accessor func(Parms) any) {
+	srv.RegisterWorldPage(path, tmpl, accessor)
+	if srv.Exporter != nil {
+		srv.Exporter.RecordPage(path, tmpl, func() any { return accessor(nil) })
+	}
+}
+
+// registerWorldResourcePage forwards to RegisterWorldResourcePage and, when
+// srv.Exporter is set, also records the route and the id set it covers so
+// it can be rendered to disk later.
+func (srv *DfServer) registerWorldResourcePage(path, tmpl string, ids func() []int, accessor func(id int) any) {
+	srv.RegisterWorldResourcePage(path, tmpl, accessor)
+	if srv.Exporter != nil {
+		srv.Exporter.RecordResourcePage(path, tmpl, ids, accessor)
+	}
+}
+
+func StartServer(world *model.DfWorld, static embed.FS, config *Config) {
 	srv := &DfServer{
 		router: mux.NewRouter().StrictSlash(true),
 		context: &DfServerContext{
 			world:     world,
 			isLoading: false,
 			progress:  &model.LoadProgress{},
+			config:    config,
 		},
+		static: static,
 	}
 	srv.loader = &loadHandler{server: srv}
 	srv.LoadTemplates()
+	srv.registerPages()
+
+	if world != nil {
+		srv.registerWorldRoutes(config)
+	}
+
+	srv.router.PathPrefix("/load").Handler(srv.loader)
+
+	// The SPA is the fallback for unmatched paths rather than a catch-all
+	// route, so routes registerWorldRoutes adds later still match first.
+	spa := spaHandler{staticFS: static, staticPath: "static", indexPath: "index.gohtml"}
+	srv.router.NotFoundHandler = spa
+
+	addr := config.Addr
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	// config.Bind plus config.Addr is what actually gets passed to
+	// ListenAndServe, so --bind has a real effect on the socket instead of
+	// only feeding the feed tag-URI host and the OpenBrowser URL below.
+	listenAddr := config.Bind + addr
 
-	srv.RegisterWorldPage("/entities", "entities.gohtml", func(p Parms) any { return grouped(srv.context.world.Entities) })
-	srv.RegisterWorldResourcePage("/entity/{id}", "entity.gohtml", func(id int) any { return srv.context.world.Entities[id] })
+	if !config.ServerMode {
+		OpenBrowser(fmt.Sprintf("http://%s%s", config.Bind, addr))
+	}
+
+	fmt.Println("Serving at", listenAddr)
+	http.ListenAndServe(listenAddr, srv)
+}
 
-	srv.RegisterWorldPage("/regions", "regions.gohtml", func(p Parms) any { return grouped(srv.context.world.Regions) })
-	srv.RegisterWorldResourcePage("/region/{id}", "region.gohtml", func(id int) any { return srv.context.world.Regions[id] })
+// registerPages registers every world page and resource page route. It is
+// shared between the live server and the static exporter, which records
+// each call via registerWorldPage/registerWorldResourcePage when
+// srv.Exporter is set.
+func (srv *DfServer) registerPages() {
+	srv.registerWorldPage("/entities", "entities.gohtml", func(p Parms) any { return grouped(srv.context.world.Entities) })
+	srv.registerWorldResourcePage("/entity/{id}", "entity.gohtml", func() []int { return util.Keys(srv.context.world.Entities) }, func(id int) any { return srv.context.world.Entities[id] })
 
-	srv.RegisterWorldPage("/sites", "sites.gohtml", func(p Parms) any { return grouped(srv.context.world.Sites) })
-	srv.RegisterWorldResourcePage("/site/{id}", "site.gohtml", func(id int) any { return srv.context.world.Sites[id] })
+	srv.registerWorldPage("/regions", "regions.gohtml", func(p Parms) any { return grouped(srv.context.world.Regions) })
+	srv.registerWorldResourcePage("/region/{id}", "region.gohtml", func() []int { return util.Keys(srv.context.world.Regions) }, func(id int) any { return srv.context.world.Regions[id] })
 
-	srv.RegisterWorldPage("/worldconstructions", "worldconstructions.gohtml", func(p Parms) any { return grouped(srv.context.world.WorldConstructions) })
-	srv.RegisterWorldResourcePage("/worldconstruction/{id}", "worldconstruction.gohtml", func(id int) any { return srv.context.world.WorldConstructions[id] })
+	srv.registerWorldPage("/sites", "sites.gohtml", func(p Parms) any { return grouped(srv.context.world.Sites) })
+	srv.registerWorldResourcePage("/site/{id}", "site.gohtml", func() []int { return util.Keys(srv.context.world.Sites) }, func(id int) any { return srv.context.world.Sites[id] })
 
-	srv.RegisterWorldPage("/artifacts", "artifacts.gohtml", func(p Parms) any { return grouped(srv.context.world.Artifacts) })
-	srv.RegisterWorldResourcePage("/artifact/{id}", "artifact.gohtml", func(id int) any { return srv.context.world.Artifacts[id] })
+	srv.registerWorldPage("/worldconstructions", "worldconstructions.gohtml", func(p Parms) any { return grouped(srv.context.world.WorldConstructions) })
+	srv.registerWorldResourcePage("/worldconstruction/{id}", "worldconstruction.gohtml", func() []int { return util.Keys(srv.context.world.WorldConstructions) }, func(id int) any { return srv.context.world.WorldConstructions[id] })
 
-	srv.RegisterWorldPage("/artforms", "artforms.gohtml", func(p Parms) any {
+	srv.registerWorldPage("/artifacts", "artifacts.gohtml", func(p Parms) any { return grouped(srv.context.world.Artifacts) })
+	srv.registerWorldResourcePage("/artifact/{id}", "artifact.gohtml", func() []int { return util.Keys(srv.context.world.Artifacts) }, func(id int) any { return srv.context.world.Artifacts[id] })
+
+	srv.registerWorldPage("/artforms", "artforms.gohtml", func(p Parms) any {
 		return struct {
 			DanceForms   map[string][]*model.DanceForm
 			MusicalForms map[string][]*model.MusicalForm
@@ -72,24 +198,14 @@ func StartServer(world *model.DfWorld, static embed.FS) {
 		}
 	})
 
-	srv.RegisterWorldPage("/writtencontents", "writtencontents.gohtml", func(p Parms) any { return grouped(srv.context.world.WrittenContents) })
-	srv.RegisterWorldResourcePage("/writtencontent/{id}", "writtencontent.gohtml", func(id int) any { return srv.context.world.WrittenContents[id] })
-
-	srv.RegisterWorldResourcePage("/hf/{id}", "hf.gohtml", func(id int) any { return srv.context.world.HistoricalFigures[id] })
-
-	srv.RegisterWorldPage("/", "eventTypes.gohtml", func(p Parms) any { return srv.context.world.AllEventTypes() })
-	srv.RegisterWorldPage("/events", "eventTypes.gohtml", func(p Parms) any { return srv.context.world.AllEventTypes() })
-	srv.RegisterWorldPage("/events/{type}", "eventType.gohtml", func(p Parms) any { return srv.context.world.EventsOfType(p["type"]) })
-
-	srv.router.PathPrefix("/load").Handler(srv.loader)
-
-	spa := spaHandler{staticFS: static, staticPath: "static", indexPath: "index.gohtml"}
-	srv.router.PathPrefix("/").Handler(spa)
+	srv.registerWorldPage("/writtencontents", "writtencontents.gohtml", func(p Parms) any { return grouped(srv.context.world.WrittenContents) })
+	srv.registerWorldResourcePage("/writtencontent/{id}", "writtencontent.gohtml", func() []int { return util.Keys(srv.context.world.WrittenContents) }, func(id int) any { return srv.context.world.WrittenContents[id] })
 
-	OpenBrowser("http://localhost:8080")
+	srv.registerWorldResourcePage("/hf/{id}", "hf.gohtml", func() []int { return util.Keys(srv.context.world.HistoricalFigures) }, func(id int) any { return srv.context.world.HistoricalFigures[id] })
 
-	fmt.Println("Serving at :8080")
-	http.ListenAndServe(":8080", srv.router)
+	srv.registerWorldPage("/", "eventTypes.gohtml", func(p Parms) any { return srv.context.world.AllEventTypes() })
+	srv.registerWorldPage("/events", "eventTypes.gohtml", func(p Parms) any { return srv.context.world.AllEventTypes() })
+	srv.registerWorldPage("/events/{type}", "eventType.gohtml", func(p Parms) any { return srv.context.world.EventsOfType(p["type"]) })
 }
 
 type spaHandler struct {
@@ -138,104 +254,6 @@ func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.FileServer(http.FS(statics)).ServeHTTP(w, r)
 }
 
-type loadHandler struct {
-	server *DfServer
-}
-
-type loadProgress struct {
-	Msg      string  `json:"msg"`
-	Progress float64 `json:"progress"`
-	Done     bool    `json:"done"`
-}
-
-func (h loadHandler) Progress() *loadProgress {
-	percent := 0.0
-	p := h.server.context.progress
-	if p.ProgressBar != nil {
-		percent = float64(p.ProgressBar.Current()*100) / float64(p.ProgressBar.Total())
-	}
-
-	return &loadProgress{
-		Msg:      h.server.context.progress.Message,
-		Progress: percent,
-		Done:     h.server.context.world != nil,
-	}
-}
-
-func (h loadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/load/progress" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-
-		json.NewEncoder(w).Encode(h.Progress())
-		return
-	}
-
-	var partitions []string
-	if runtime.GOOS == "windows" {
-		ps, _ := disk.Partitions(false)
-		partitions = util.Map(ps, func(p disk.PartitionStat) string { return p.Mountpoint + `\` })
-	} else {
-		partitions = append(partitions, "/")
-	}
-
-	path := r.URL.Query().Get("p")
-
-	p := &paths{
-		Partitions: partitions,
-		Current:    path,
-	}
-	if p.Current == "" {
-		p.Current = "."
-	}
-	var err error
-	p.Current, err = filepath.Abs(p.Current)
-	if err != nil {
-		httpError(w, err)
-		return
-	}
-
-	if f, err := os.Stat(p.Current); err == nil {
-		if f.IsDir() {
-			p.List, err = ioutil.ReadDir(p.Current)
-			if err != nil {
-				httpError(w, err)
-				return
-			}
-
-			err = h.server.templates.Render(w, "load.gohtml", p)
-			if err != nil {
-				httpError(w, err)
-			}
-			return
-		} else {
-			h.server.context.isLoading = true
-			h.server.context.world = nil
-			go loadWorld(h.server, p.Current)
-			http.Redirect(w, r, "/", http.StatusSeeOther)
-			return
-		}
-	}
-	http.Redirect(w, r, "/load", http.StatusSeeOther)
-}
-
-func isLegendsXml(f fs.FileInfo) bool {
-	return strings.HasSuffix(f.Name(), "-legends.xml")
-}
-
-func loadWorld(server *DfServer, file string) {
-	runtime.GC()
-	wrld, _ := model.Parse(file, server.context.progress)
-	server.context.world = wrld
-	server.context.isLoading = false
-}
-
-type paths struct {
-	Current    string
-	List       []fs.FileInfo
-	Partitions []string
-}
-
 func (srv *DfServer) renderLoading(w http.ResponseWriter, r *http.Request) {
 	if srv.context.isLoading {
 		err := srv.templates.Render(w, "loading.gohtml", srv.loader.Progress())
@@ -252,6 +270,20 @@ func httpError(w http.ResponseWriter, err error) {
 	fmt.Println(err)
 }
 
+// feedFunctions returns the subset of template functions feed entries need
+// to render the same hf/entity/site/region links the pages use.
+func feedFunctions() template.FuncMap {
+	return template.FuncMap{
+		"hf":     model.LinkHf,
+		"entity": model.LinkEntity,
+		"site":   model.LinkSite,
+		"region": model.LinkRegion,
+		"html": func(value any) template.HTML {
+			return template.HTML(fmt.Sprint(value))
+		},
+	}
+}
+
 type namedTyped interface {
 	model.Named
 	model.Typed